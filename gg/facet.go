@@ -6,6 +6,7 @@ package gg
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 
 	"github.com/aclements/go-gg/generic"
@@ -16,17 +17,11 @@ import (
 // repeated in all facets. ggplot2 apparently does this when the
 // faceting variable isn't in one of the data frames.
 
-// TODO: FacetWrap
-
 // TODO: Subplot is getting rather complicated. If I want to make
 // facets only use public APIs, perhaps gg itself should only know
 // about some interface for table group labels that provides a layout
 // manager and the layout logic should live with the facets.
 
-// TODO: This is very nearly flexible enough to make pairwise plots.
-
-// TODO: Is this flexible enough to make marginal distribution plots?
-
 // FacetCommon is the base type for plot faceting operations. Faceting
 // is a grouping operation that subdivides a plot into subplots based
 // on the values in data column. Faceting operations may be composed:
@@ -112,33 +107,7 @@ func (f *FacetCommon) apply(p *Plot, dir string) {
 	// Collect grouped values. If there was already grouping
 	// structure, it's possible we'll have multiple groups with
 	// the same value for Col.
-	type valInfo struct {
-		index int
-		label string
-	}
-	var valType reflect.Type
-	vals := make(map[interface{}]*valInfo)
-	for i, gid := range grouped.Tables() {
-		val := gid.Label()
-		if _, ok := vals[val]; !ok {
-			vals[val] = &valInfo{len(vals), f.Labeler(val)}
-		}
-		if i == 0 {
-			valType = reflect.TypeOf(val)
-		}
-	}
-
-	// If f.Col is orderable, order and re-index values.
-	if generic.CanOrderR(valType.Kind()) {
-		valSeq := reflect.MakeSlice(reflect.SliceOf(valType), 0, len(vals))
-		for val := range vals {
-			valSeq = reflect.Append(valSeq, reflect.ValueOf(val))
-		}
-		generic.Sort(valSeq.Interface())
-		for i := 0; i < valSeq.Len(); i++ {
-			vals[valSeq.Index(i).Interface()].index = i
-		}
-	}
+	vals := collectFacetValues(grouped, f.Labeler)
 
 	// Find existing subplots, split existing subplots and bands
 	// into len(vals) new subplots and bands, and transform each
@@ -231,6 +200,184 @@ func (f *FacetCommon) apply(p *Plot, dir string) {
 	p.SetData(ndata.Done())
 }
 
+// facetValInfo records the presentation index and label of one
+// distinct value of a facet's Col, as collected by
+// collectFacetValues.
+type facetValInfo struct {
+	index int
+	label string
+}
+
+// collectFacetValues collects the distinct group labels of grouped
+// (as produced by table.GroupBy on a facet's Col) and assigns each an
+// index: in value order if the labels are orderable, otherwise in the
+// order they were first seen. Each value is labeled by calling
+// labeler on it.
+func collectFacetValues(grouped table.Grouping, labeler func(interface{}) string) map[interface{}]*facetValInfo {
+	var valType reflect.Type
+	vals := make(map[interface{}]*facetValInfo)
+	for i, gid := range grouped.Tables() {
+		val := gid.Label()
+		if _, ok := vals[val]; !ok {
+			vals[val] = &facetValInfo{len(vals), labeler(val)}
+		}
+		if i == 0 {
+			valType = reflect.TypeOf(val)
+		}
+	}
+	if valType == nil {
+		// grouped has no groups at all.
+		return vals
+	}
+
+	// If the values are orderable, order and re-index them.
+	if generic.CanOrderR(valType.Kind()) {
+		valSeq := reflect.MakeSlice(reflect.SliceOf(valType), 0, len(vals))
+		for val := range vals {
+			valSeq = reflect.Append(valSeq, reflect.ValueOf(val))
+		}
+		generic.Sort(valSeq.Interface())
+		for i := 0; i < valSeq.Len(); i++ {
+			vals[valSeq.Index(i).Interface()].index = i
+		}
+	}
+	return vals
+}
+
+// FacetWrap splits a plot into a grid of subplots based on the
+// distinct values of a single column, laid out row by row and
+// wrapping to a new row once a row holds ncol subplots (whether ncol
+// was given as NCol or computed from NRow), rather than laying them
+// out as a single strip like FacetX or FacetY.
+type FacetWrap struct {
+	// Col names the column to facet by. Each distinct value of
+	// this column will become a separate subplot. If Col is
+	// orderable, the subplots will be in value order; otherwise,
+	// they will be in index order.
+	Col string
+
+	// NRow and NCol specify the number of rows and columns in
+	// the wrapped grid. If both are 0, they default to a near
+	// square grid (NCol = ceil(sqrt(n))). If exactly one is 0,
+	// it is computed from the other as ceil(n / other), where n
+	// is the number of distinct values of Col.
+	NRow, NCol int
+
+	// Scales specifies how X and Y scales are shared between
+	// the subplots created by this facet: "fixed" (the
+	// default) shares both scales across all subplots; "free"
+	// gives every subplot independent X and Y scales; "free_x"
+	// and "free_y" free just the X or Y scale, respectively.
+	//
+	// Unlike FacetX and FacetY, which can share scales across a
+	// whole row or column of a regular grid, wrapped subplots
+	// don't generally share whole rows or columns with one
+	// another, so non-fixed scales are cloned per subplot
+	// rather than per band.
+	Scales string
+
+	// Labeler is a function that constructs facet labels from
+	// data values. If this is nil, the default is fmt.Sprint.
+	Labeler func(interface{}) string
+}
+
+// wrapGridDims computes the number of columns and rows of a wrapped
+// grid holding n cells, given the NCol/NRow the caller requested (0
+// meaning "compute this one"). If both are 0, it picks a near-square
+// grid, favoring a wider-than-tall layout.
+func wrapGridDims(n, ncol, nrow int) (int, int) {
+	switch {
+	case ncol == 0 && nrow == 0:
+		ncol = int(math.Ceil(math.Sqrt(float64(n))))
+		nrow = int(math.Ceil(float64(n) / float64(ncol)))
+	case ncol == 0:
+		ncol = int(math.Ceil(float64(n) / float64(nrow)))
+	case nrow == 0:
+		nrow = int(math.Ceil(float64(n) / float64(ncol)))
+	}
+	return ncol, nrow
+}
+
+func (f FacetWrap) Apply(p *Plot) {
+	if f.Labeler == nil {
+		f.Labeler = func(x interface{}) string { return fmt.Sprint(x) }
+	}
+
+	grouped := table.GroupBy(p.Data(), f.Col)
+
+	// Collect grouped values, as in FacetCommon.apply.
+	vals := collectFacetValues(grouped, f.Labeler)
+
+	// Compute the grid dimensions.
+	n := len(vals)
+	ncol, nrow := wrapGridDims(n, f.NCol, f.NRow)
+
+	// Find existing subplots and split each into an ncol x nrow
+	// grid of new subplots, one per distinct value of f.Col.
+	// Unlike FacetX/FacetY, each new subplot gets its own label
+	// band (placed above the subplot) rather than a band shared
+	// with the rest of its row or column.
+	type cellScale struct {
+		sub   *subplot
+		scale Scaler
+	}
+	subplots := make(map[*subplot][]*subplot)
+	scales := make(map[cellScale]Scaler)
+	var ndata table.GroupingBuilder
+	for _, gid := range grouped.Tables() {
+		// Find subplot by walking up group hierarchy.
+		sub := subplotOf(gid)
+
+		// Split old subplot into an ncol x nrow grid of new
+		// subplots, each with its own band.
+		nsubplots := subplots[sub]
+		if nsubplots == nil {
+			nsubplots = make([]*subplot, n)
+			for _, val := range vals {
+				band := &subplotBand{parent: sub.vBand, label: val.label}
+				nsubplots[val.index] = &subplot{
+					parent: sub,
+					x:      sub.x*ncol + val.index%ncol,
+					y:      sub.y*nrow + val.index/ncol,
+					vBand:  band,
+					hBand:  sub.hBand,
+				}
+			}
+			subplots[sub] = nsubplots
+		}
+
+		// Map this group to its new subplot.
+		nsubplot := nsubplots[vals[gid.Label()].index]
+		ngid := gid.Parent().Extend(nsubplot)
+		ndata.Add(ngid, grouped.Table(gid))
+
+		// Split scales if requested. Since wrapped subplots
+		// don't share whole rows or columns of the grid, we
+		// clone a distinct scaler for each new subplot rather
+		// than for each band.
+		if f.Scales == "free" || f.Scales == "free_x" {
+			scaler := p.GetScale("x", gid)
+			nscaler := scales[cellScale{nsubplot, scaler}]
+			if nscaler == nil {
+				nscaler = scaler.CloneScaler()
+				scales[cellScale{nsubplot, scaler}] = nscaler
+			}
+			p.SetScaleAt("x", nscaler, ngid)
+		}
+		if f.Scales == "free" || f.Scales == "free_y" {
+			scaler := p.GetScale("y", gid)
+			nscaler := scales[cellScale{nsubplot, scaler}]
+			if nscaler == nil {
+				nscaler = scaler.CloneScaler()
+				scales[cellScale{nsubplot, scaler}] = nscaler
+			}
+			p.SetScaleAt("y", nscaler, ngid)
+		}
+	}
+
+	p.SetData(ndata.Done())
+}
+
 // subplotBand represents a rectangular group of subplots in either a
 // vertical group (with a label on top) or a horizontal group (with a
 // label to the right).
@@ -0,0 +1,149 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gg
+
+import (
+	"github.com/aclements/go-gg/table"
+)
+
+// FacetPairs lays out an NxN grid of subplots from a list of N
+// columns, where cell (i, j) plots Cols[j] on the X axis and
+// Cols[i] on the Y axis. This is also known as a scatterplot
+// matrix.
+//
+// Each off-diagonal cell's data is rewritten to expose two generic
+// columns, ".x" and ".y", drawn from Cols[j] and Cols[i], so layers
+// added after FacetPairs can simply plot ".x" against ".y" without
+// knowing which pair of columns a given cell came from. A diagonal
+// cell only gets ".x", since it has a single variable, Cols[i]; see
+// Diag.
+//
+// The X scale is shared down each column and the Y scale is shared
+// across the off-diagonal cells of each row, mirroring the way
+// FacetX/FacetY share scales within a band. Diagonal cells keep the
+// Y scale of the subplot they were split from.
+type FacetPairs struct {
+	// Cols is the list of columns to plot pairwise. The
+	// resulting grid has len(Cols) rows and len(Cols) columns.
+	Cols []string
+
+	// Diag, if non-nil, builds the layer shown in diagonal cell
+	// (i, i). It is passed Cols[i] and the subplot's original
+	// data with all of its columns (not the ".x" column). If
+	// Diag is nil, diagonal cells are left blank except for
+	// their row/column label.
+	Diag func(col string, g table.Grouping) Plotter
+}
+
+// pairCellCoord computes the position of the (row, col) cell of an
+// n x n FacetPairs grid nested inside the subplot at (subX, subY).
+func pairCellCoord(subX, subY, n, row, col int) (x, y int) {
+	return subX*n + col, subY*n + row
+}
+
+// pairCellData builds the ".x" (and, off the diagonal, ".y") columns
+// for one FacetPairs cell from t's xCol and yCol. A diagonal cell
+// (diag true) only gets ".x", since it plots the single variable
+// xCol (== yCol) rather than a pair; see FacetPairs.Diag.
+func pairCellData(t *table.Table, xCol, yCol string, diag bool) *table.Table {
+	var b table.Builder
+	b.Add(".x", t.Column(xCol))
+	if !diag {
+		b.Add(".y", t.Column(yCol))
+	}
+	return b.Done()
+}
+
+func (f FacetPairs) Apply(p *Plot) {
+	n := len(f.Cols)
+
+	grouped := p.Data()
+
+	// Find existing subplots and split each into an NxN grid of
+	// new subplots, sharing a column's vBand (and hence X scale)
+	// down every row and a row's hBand (and hence Y scale)
+	// across every column, mirroring SplitXScales/SplitYScales.
+	type cellSubplot struct {
+		row, col int
+	}
+	type bandScale struct {
+		band  *subplotBand
+		scale Scaler
+	}
+	subplots := make(map[*subplot]map[cellSubplot]*subplot)
+	vBands := make(map[*subplotBand][]*subplotBand)
+	hBands := make(map[*subplotBand][]*subplotBand)
+	xScales := make(map[bandScale]Scaler)
+	yScales := make(map[bandScale]Scaler)
+	var ndata table.GroupingBuilder
+	for _, gid := range grouped.Tables() {
+		sub := subplotOf(gid)
+		t := grouped.Table(gid)
+
+		nsubplots := subplots[sub]
+		if nsubplots == nil {
+			nvbands := make([]*subplotBand, n)
+			nhbands := make([]*subplotBand, n)
+			for k, col := range f.Cols {
+				nvbands[k] = &subplotBand{parent: sub.vBand, label: col}
+				nhbands[k] = &subplotBand{parent: sub.hBand, label: col}
+			}
+			vBands[sub.vBand] = nvbands
+			hBands[sub.hBand] = nhbands
+
+			nsubplots = make(map[cellSubplot]*subplot, n*n)
+			subplots[sub] = nsubplots
+		}
+
+		nvbands, nhbands := vBands[sub.vBand], hBands[sub.hBand]
+		for i, yCol := range f.Cols {
+			for j, xCol := range f.Cols {
+				cell := cellSubplot{i, j}
+				ns := nsubplots[cell]
+				if ns == nil {
+					px, py := pairCellCoord(sub.x, sub.y, n, i, j)
+					ns = &subplot{
+						parent: sub,
+						x:      px,
+						y:      py,
+						vBand:  nvbands[j],
+						hBand:  nhbands[i],
+					}
+					nsubplots[cell] = ns
+				}
+				ngid := gid.Extend(ns)
+
+				ndata.Add(ngid, pairCellData(t, xCol, yCol, i == j))
+
+				// Share the X scale down this column and
+				// the Y scale across this row, cloning a
+				// scaler the first time each band is seen.
+				xScaler := p.GetScale("x", gid)
+				nxScaler := xScales[bandScale{nvbands[j], xScaler}]
+				if nxScaler == nil {
+					nxScaler = xScaler.CloneScaler()
+					xScales[bandScale{nvbands[j], xScaler}] = nxScaler
+				}
+				p.SetScaleAt("x", nxScaler, ngid)
+
+				if i != j {
+					yScaler := p.GetScale("y", gid)
+					nyScaler := yScales[bandScale{nhbands[i], yScaler}]
+					if nyScaler == nil {
+						nyScaler = yScaler.CloneScaler()
+						yScales[bandScale{nhbands[i], yScaler}] = nyScaler
+					}
+					p.SetScaleAt("y", nyScaler, ngid)
+				}
+
+				if i == j && f.Diag != nil {
+					p.Add(ngid, f.Diag(xCol, grouped.Table(gid)))
+				}
+			}
+		}
+	}
+
+	p.SetData(ndata.Done())
+}
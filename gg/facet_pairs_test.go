@@ -0,0 +1,71 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aclements/go-gg/table"
+)
+
+func TestPairCellCoord(t *testing.T) {
+	// A 3x3 grid nested in subplot (2, 1): every cell must land at
+	// a distinct position, and row/col must map to y/x respectively.
+	const n = 3
+	seen := make(map[[2]int]bool)
+	for row := 0; row < n; row++ {
+		for col := 0; col < n; col++ {
+			x, y := pairCellCoord(2, 1, n, row, col)
+			if got, want := x, 2*n+col; got != want {
+				t.Errorf("pairCellCoord row=%d col=%d: x = %d, want %d", row, col, got, want)
+			}
+			if got, want := y, 1*n+row; got != want {
+				t.Errorf("pairCellCoord row=%d col=%d: y = %d, want %d", row, col, got, want)
+			}
+			pos := [2]int{x, y}
+			if seen[pos] {
+				t.Fatalf("row=%d col=%d: position %v reused", row, col, pos)
+			}
+			seen[pos] = true
+		}
+	}
+}
+
+func TestPairCellDataDiagonalOnlyX(t *testing.T) {
+	tab := mustBuildTable(t, "a", []float64{1, 2}, "b", []float64{3, 4})
+
+	diag := pairCellData(tab, "a", "b", true)
+	if got, want := diag.Columns(), []string{".x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diagonal cell columns = %v, want %v", got, want)
+	}
+	if got, want := diag.Column(".x"), []float64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diagonal cell .x = %v, want %v", got, want)
+	}
+}
+
+func TestPairCellDataOffDiagonalHasBoth(t *testing.T) {
+	tab := mustBuildTable(t, "a", []float64{1, 2}, "b", []float64{3, 4})
+
+	off := pairCellData(tab, "a", "b", false)
+	if got, want := off.Columns(), []string{".x", ".y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("off-diagonal cell columns = %v, want %v", got, want)
+	}
+	if got, want := off.Column(".x"), []float64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("off-diagonal cell .x = %v, want %v", got, want)
+	}
+	if got, want := off.Column(".y"), []float64{3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("off-diagonal cell .y = %v, want %v", got, want)
+	}
+}
+
+func mustBuildTable(t *testing.T, cols ...interface{}) *table.Table {
+	t.Helper()
+	var b table.Builder
+	for i := 0; i < len(cols); i += 2 {
+		b.Add(cols[i].(string), cols[i+1])
+	}
+	return b.Done()
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gg
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aclements/go-gg/table"
+)
+
+// groupsOf builds a Grouping with one empty-ish Table per val, each
+// identified by a GroupID labeled with that value, for exercising
+// functions that only look at group labels.
+func groupsOf(vals ...interface{}) table.Grouping {
+	var b table.Builder
+	b.Add("x", []int{0})
+	t := b.Done()
+
+	var out table.GroupingBuilder
+	for _, v := range vals {
+		out.Add(table.RootGroupID.Extend(v), t)
+	}
+	return out.Done()
+}
+
+func labeler(v interface{}) string { return fmt.Sprint(v) }
+
+func TestCollectFacetValuesOrderable(t *testing.T) {
+	vals := collectFacetValues(groupsOf(3, 1, 2, 1), labeler)
+	if len(vals) != 3 {
+		t.Fatalf("len(vals) = %d, want 3", len(vals))
+	}
+	want := map[interface{}]int{1: 0, 2: 1, 3: 2}
+	for v, wantIdx := range want {
+		if got := vals[v].index; got != wantIdx {
+			t.Errorf("vals[%v].index = %d, want %d", v, got, wantIdx)
+		}
+	}
+}
+
+func TestCollectFacetValuesUnorderablePreservesOrder(t *testing.T) {
+	type unorderable struct{ n int }
+	a, b, c := unorderable{3}, unorderable{1}, unorderable{2}
+	vals := collectFacetValues(groupsOf(a, b, c, a), labeler)
+	if len(vals) != 3 {
+		t.Fatalf("len(vals) = %d, want 3", len(vals))
+	}
+	want := map[interface{}]int{a: 0, b: 1, c: 2}
+	for v, wantIdx := range want {
+		if got := vals[v].index; got != wantIdx {
+			t.Errorf("vals[%v].index = %d, want %d", v, got, wantIdx)
+		}
+	}
+}
+
+func TestCollectFacetValuesEmpty(t *testing.T) {
+	// A Grouping with no groups must not panic trying to order
+	// values by a nil value type.
+	vals := collectFacetValues(groupsOf(), labeler)
+	if len(vals) != 0 {
+		t.Errorf("len(vals) = %d, want 0", len(vals))
+	}
+}
+
+func TestWrapGridDims(t *testing.T) {
+	cases := []struct {
+		n, ncol, nrow int
+		wantC, wantR  int
+	}{
+		{9, 0, 0, 3, 3},
+		{10, 0, 0, 4, 3},
+		{10, 5, 0, 5, 2},
+		{10, 0, 5, 2, 5},
+		{10, 3, 4, 3, 4},
+	}
+	for _, c := range cases {
+		gotC, gotR := wrapGridDims(c.n, c.ncol, c.nrow)
+		if gotC != c.wantC || gotR != c.wantR {
+			t.Errorf("wrapGridDims(%d, %d, %d) = (%d, %d), want (%d, %d)",
+				c.n, c.ncol, c.nrow, gotC, gotR, c.wantC, c.wantR)
+		}
+	}
+}
+
+func TestSubplotOfWalksUpToNearestLabel(t *testing.T) {
+	if got := subplotOf(table.RootGroupID); got != rootSubplot {
+		t.Errorf("subplotOf(RootGroupID) = %v, want rootSubplot", got)
+	}
+
+	sub := &subplot{x: 1, y: 2}
+	gid := table.RootGroupID.Extend(sub).Extend("a").Extend("b")
+	if got := subplotOf(gid); got != sub {
+		t.Errorf("subplotOf found %v, want %v", got, sub)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gg
+
+import (
+	"github.com/aclements/go-gg/table"
+)
+
+// marginalCommon is the base type for MarginalX and MarginalY.
+type marginalCommon struct {
+	// Stat builds the marginal layer from the data of a single
+	// subplot. It is typically a layer factory such as a density
+	// or histogram stat, or a boxplot layer.
+	Stat func(g table.Grouping) Plotter
+}
+
+// MarginalX adds a thin subplot along the top edge of every subplot
+// in p, showing a univariate summary (built by Stat) of the X
+// values of that subplot. The marginal subplot shares its parent
+// subplot's X scale, so the two line up.
+type MarginalX marginalCommon
+
+// MarginalY adds a thin subplot along the right edge of every
+// subplot in p, showing a univariate summary (built by Stat) of the
+// Y values of that subplot. The marginal subplot shares its parent
+// subplot's Y scale, so the two line up.
+type MarginalY marginalCommon
+
+func (m MarginalX) Apply(p *Plot) {
+	(*marginalCommon)(&m).apply(p, "x")
+}
+
+func (m MarginalY) Apply(p *Plot) {
+	(*marginalCommon)(&m).apply(p, "y")
+}
+
+// marginalPair is the pair of new subplots a single existing subplot
+// is split into: a thin strip showing the marginal summary, and the
+// original subplot carrying on its content.
+type marginalPair struct {
+	margin, content *subplot
+}
+
+// marginalCoords computes the coordinates of the margin and content
+// subplots that a subplot at (x, y) splits into for the given
+// direction ("x" or "y"). For dir == "x", the margin is placed above
+// the content (lower y); for dir == "y", the margin is placed to the
+// right of the content (higher x).
+func marginalCoords(dir string, x, y int) (mx, my, cx, cy int) {
+	if dir == "x" {
+		return x, y * 2, x, y*2 + 1
+	}
+	return x*2 + 1, y, x * 2, y
+}
+
+func (m *marginalCommon) apply(p *Plot, dir string) {
+	grouped := p.Data()
+
+	// Split each existing subplot into two new subplots laid out
+	// one before the other along the axis perpendicular to dir:
+	// a margin strip (holding the summary) and a content cell
+	// (holding the original data), mirroring the way FacetX/Y
+	// allocate a distinct x or y per new subplot rather than
+	// reusing the parent's coordinates.
+	subplots := make(map[*subplot]marginalPair)
+	var ndata table.GroupingBuilder
+	for _, gid := range grouped.Tables() {
+		sub := subplotOf(gid)
+
+		sp, ok := subplots[sub]
+		if !ok {
+			mx, my, cx, cy := marginalCoords(dir, sub.x, sub.y)
+			if dir == "x" {
+				mBand := &subplotBand{parent: sub.hBand, label: ""}
+				cBand := &subplotBand{parent: sub.hBand, label: ""}
+				sp = marginalPair{
+					margin:  &subplot{parent: sub, x: mx, y: my, vBand: sub.vBand, hBand: mBand},
+					content: &subplot{parent: sub, x: cx, y: cy, vBand: sub.vBand, hBand: cBand},
+				}
+			} else {
+				mBand := &subplotBand{parent: sub.vBand, label: ""}
+				cBand := &subplotBand{parent: sub.vBand, label: ""}
+				sp = marginalPair{
+					margin:  &subplot{parent: sub, x: mx, y: my, vBand: mBand, hBand: sub.hBand},
+					content: &subplot{parent: sub, x: cx, y: cy, vBand: cBand, hBand: sub.hBand},
+				}
+			}
+			subplots[sub] = sp
+
+			// Share the parent cell's scale along dir so the
+			// marginal plot lines up with the data it
+			// summarizes.
+			mgid := gid.Parent().Extend(sp.margin)
+			p.SetScaleAt(dir, p.GetScale(dir, gid), mgid)
+		}
+
+		cgid := gid.Parent().Extend(sp.content)
+		ndata.Add(cgid, grouped.Table(gid))
+	}
+	p.SetData(ndata.Done())
+
+	// Inject a new layer built from the user-supplied stat over
+	// each subplot's own data.
+	for _, gid := range grouped.Tables() {
+		sub := subplotOf(gid)
+		mgid := gid.Parent().Extend(subplots[sub].margin)
+		p.Add(mgid, m.Stat(grouped.Table(gid)))
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gg
+
+import "testing"
+
+func TestMarginalCoords(t *testing.T) {
+	cases := []struct {
+		dir            string
+		x, y           int
+		mx, my, cx, cy int
+	}{
+		{"x", 0, 0, 0, 0, 0, 1},
+		{"x", 3, 2, 3, 4, 3, 5},
+		{"y", 0, 0, 1, 0, 0, 0},
+		{"y", 2, 3, 5, 3, 4, 3},
+	}
+	for _, c := range cases {
+		mx, my, cx, cy := marginalCoords(c.dir, c.x, c.y)
+		if mx != c.mx || my != c.my || cx != c.cx || cy != c.cy {
+			t.Errorf("marginalCoords(%q, %d, %d) = (%d, %d, %d, %d), want (%d, %d, %d, %d)",
+				c.dir, c.x, c.y, mx, my, cx, cy, c.mx, c.my, c.cx, c.cy)
+		}
+		if mx == cx && my == cy {
+			t.Errorf("marginalCoords(%q, %d, %d): margin and content collide at (%d, %d)", c.dir, c.x, c.y, mx, my)
+		}
+	}
+}
+
+// TestMarginalCoordsDistinctAcrossSubplots verifies that two
+// different parent subplots never produce colliding margin/content
+// coordinates, which would merge unrelated subplots together.
+func TestMarginalCoordsDistinctAcrossSubplots(t *testing.T) {
+	type coord struct{ x, y int }
+	for _, dir := range []string{"x", "y"} {
+		seen := make(map[coord]bool)
+		for x := 0; x < 3; x++ {
+			for y := 0; y < 3; y++ {
+				mx, my, cx, cy := marginalCoords(dir, x, y)
+				for _, c := range []coord{{mx, my}, {cx, cy}} {
+					if seen[c] {
+						t.Fatalf("dir %q: coordinate %v reused across subplots", dir, c)
+					}
+					seen[c] = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,469 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gg
+
+import (
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/aclements/go-gg/table"
+)
+
+// StatSmooth fits a smooth LOESS (locally weighted regression)
+// curve through the Y column as a function of the X column, adding
+// a new ".fit" column to each group of the plot's data.
+type StatSmooth struct {
+	X, Y string
+
+	// Span is the fraction of points used in each local
+	// regression. It must be in (0, 1]. If 0, it defaults to
+	// 0.5.
+	Span float64
+
+	// Degree is the degree of the local regression: 0 for a
+	// locally weighted mean, 1 for a locally weighted line, 2
+	// for a locally weighted quadratic. If 0 and Span is also 0
+	// (that is, the zero StatSmooth), Degree defaults to 1; set
+	// Span explicitly to use a locally weighted mean.
+	Degree int
+
+	// Family selects the weight function: "gaussian" (the
+	// default) uses tricube weights falling off smoothly to 0
+	// at the neighborhood boundary; "symmetric" additionally
+	// re-weights by residual size to reduce the influence of
+	// outliers.
+	Family string
+}
+
+func (s StatSmooth) Apply(p *Plot) {
+	span, degree, family := s.Span, s.Degree, s.Family
+	if span == 0 {
+		span = 0.5
+	}
+	if degree == 0 && s.Span == 0 {
+		degree = 1
+	}
+	if family == "" {
+		family = "gaussian"
+	}
+
+	applyPerGroup(p, func(t *table.Table) (names []string, cols []interface{}) {
+		xs := columnFloats(t, s.X)
+		ys := columnFloats(t, s.Y)
+		fit := loess(xs, ys, span, degree, family)
+		return []string{".fit"}, []interface{}{fit}
+	})
+}
+
+// StatRollingMean computes a moving average of the Y column over a
+// window of Size consecutive rows (in the existing row order, which
+// for time series data is typically time order), adding a new
+// ".fit" column to each group of the plot's data.
+type StatRollingMean struct {
+	Y string
+
+	// Size is the number of rows in each window.
+	Size int
+
+	// Trailing indicates that each window ends at (rather than
+	// is centered on) the row it's reported for. The default,
+	// false, centers the window on each row.
+	Trailing bool
+}
+
+func (s StatRollingMean) Apply(p *Plot) {
+	applyPerGroup(p, func(t *table.Table) ([]string, []interface{}) {
+		ys := columnFloats(t, s.Y)
+		fit := rolling(ys, s.Size, s.Trailing, mean)
+		return []string{".fit"}, []interface{}{fit}
+	})
+}
+
+// StatRollingMedian is like StatRollingMean but computes a rolling
+// median instead of a rolling mean.
+type StatRollingMedian struct {
+	Y string
+
+	// Size is the number of rows in each window.
+	Size int
+
+	// Trailing indicates that each window ends at (rather than
+	// is centered on) the row it's reported for. The default,
+	// false, centers the window on each row.
+	Trailing bool
+}
+
+func (s StatRollingMedian) Apply(p *Plot) {
+	applyPerGroup(p, func(t *table.Table) ([]string, []interface{}) {
+		ys := columnFloats(t, s.Y)
+		fit := rolling(ys, s.Size, s.Trailing, median)
+		return []string{".fit"}, []interface{}{fit}
+	})
+}
+
+// StatLinearFit fits a least-squares line through the Y column as a
+// function of the X column, adding a ".fit" column to each group of
+// the plot's data. If Level is non-zero, it additionally adds
+// ".ymin" and ".ymax" columns giving a confidence band for the fit
+// at the given confidence level (for example, 0.95).
+type StatLinearFit struct {
+	X, Y string
+
+	// Level is the confidence level for the fit's confidence
+	// band, such as 0.95. If 0, no confidence band is computed.
+	Level float64
+}
+
+func (s StatLinearFit) Apply(p *Plot) {
+	applyPerGroup(p, func(t *table.Table) ([]string, []interface{}) {
+		xs := columnFloats(t, s.X)
+		ys := columnFloats(t, s.Y)
+		slope, intercept := linearFit(xs, ys)
+
+		fit := make([]float64, len(xs))
+		for i, x := range xs {
+			fit[i] = slope*x + intercept
+		}
+		if s.Level == 0 {
+			return []string{".fit"}, []interface{}{fit}
+		}
+
+		ymin, ymax := linearFitBand(xs, ys, slope, intercept, s.Level)
+		return []string{".fit", ".ymin", ".ymax"}, []interface{}{fit, ymin, ymax}
+	})
+}
+
+// applyPerGroup adds the columns computed by fn to every leaf group
+// of p's data, leaving the existing columns untouched.
+func applyPerGroup(p *Plot, fn func(t *table.Table) (names []string, cols []interface{})) {
+	grouped := p.Data()
+
+	var ndata table.GroupingBuilder
+	for _, gid := range grouped.Tables() {
+		t := grouped.Table(gid)
+
+		var b table.Builder
+		for _, name := range t.Columns() {
+			if cv, ok := t.Const(name); ok {
+				b.AddConst(name, cv)
+				continue
+			}
+			b.Add(name, t.Column(name))
+		}
+		names, cols := fn(t)
+		for i, name := range names {
+			b.Add(name, cols[i])
+		}
+		ndata.Add(gid, b.Done())
+	}
+	p.SetData(ndata.Done())
+}
+
+// columnFloats returns column name of t as a []float64, converting
+// from whatever numeric type it's actually stored as.
+func columnFloats(t *table.Table, name string) []float64 {
+	seq := reflect.ValueOf(t.MustColumn(name))
+	out := make([]float64, seq.Len())
+	for i := range out {
+		out[i] = toFloat(seq.Index(i))
+	}
+	return out
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	}
+	panic("gg: non-numeric column")
+}
+
+// loess computes a LOESS smooth of ys as a function of xs, returning
+// one fitted value per input point. For each point, it takes the
+// k = ceil(span*n) nearest neighbors by |x - x_i|, weights them with
+// a tricube kernel (optionally re-weighted by residual size for the
+// "symmetric" family), and fits a weighted local polynomial of the
+// given degree.
+func loess(xs, ys []float64, span float64, degree int, family string) []float64 {
+	n := len(xs)
+	k := int(math.Ceil(span * float64(n)))
+	if k < degree+1 {
+		k = degree + 1
+	}
+	if k > n {
+		k = n
+	}
+
+	fit := make([]float64, n)
+	robust := make([]float64, n)
+	for i := range robust {
+		robust[i] = 1
+	}
+
+	iters := 1
+	if family == "symmetric" {
+		iters = 4
+	}
+
+	type neighbor struct {
+		i    int
+		dist float64
+	}
+	neighbors := make([]neighbor, n)
+	nxs := make([]float64, k)
+	nys := make([]float64, k)
+	nw := make([]float64, k)
+
+	for iter := 0; iter < iters; iter++ {
+		for i, xi := range xs {
+			for j, xj := range xs {
+				neighbors[j] = neighbor{j, math.Abs(xj - xi)}
+			}
+			sort.Slice(neighbors, func(a, b int) bool {
+				return neighbors[a].dist < neighbors[b].dist
+			})
+			dmax := neighbors[k-1].dist
+			if dmax == 0 {
+				dmax = 1
+			}
+
+			for idx, nb := range neighbors[:k] {
+				d := nb.dist / dmax
+				if d > 1 {
+					d = 1
+				}
+				nxs[idx] = xs[nb.i]
+				nys[idx] = ys[nb.i]
+				nw[idx] = math.Pow(1-d*d*d, 3) * robust[nb.i]
+			}
+
+			fit[i] = weightedPolyFit(nxs, nys, nw, xi, degree)
+		}
+
+		if iter+1 < iters {
+			residuals := make([]float64, n)
+			for i := range ys {
+				residuals[i] = math.Abs(ys[i] - fit[i])
+			}
+			s := median(append([]float64{}, residuals...))
+			if s == 0 {
+				// The fit already passes through (nearly)
+				// every point, so there's nothing to
+				// down-weight.
+				for i := range robust {
+					robust[i] = 1
+				}
+				continue
+			}
+			for i, r := range residuals {
+				u := r / (6 * s)
+				if u >= 1 {
+					robust[i] = 0
+				} else {
+					robust[i] = (1 - u*u) * (1 - u*u)
+				}
+			}
+		}
+	}
+
+	return fit
+}
+
+// weightedPolyFit fits a degree-th order polynomial to (xs, ys)
+// using the given per-point weights, by solving the weighted normal
+// equations, and returns its value at x0. The fit is computed in
+// powers of (x - x0) so that the requested value is simply the
+// constant term, which keeps the normal equations well conditioned
+// regardless of the magnitude of x0.
+func weightedPolyFit(xs, ys, w []float64, x0 float64, degree int) float64 {
+	m := degree + 1
+
+	a := make([][]float64, m)
+	for i := range a {
+		a[i] = make([]float64, m)
+	}
+	b := make([]float64, m)
+
+	pow := make([]float64, 2*m-1)
+	for i := range xs {
+		dx := xs[i] - x0
+		pow[0] = 1
+		for k := 1; k < len(pow); k++ {
+			pow[k] = pow[k-1] * dx
+		}
+		for p := 0; p < m; p++ {
+			for q := 0; q < m; q++ {
+				a[p][q] += w[i] * pow[p+q]
+			}
+			b[p] += w[i] * pow[p] * ys[i]
+		}
+	}
+
+	if beta := solveLinear(a, b); beta != nil {
+		return beta[0]
+	}
+
+	// The normal equations are singular (e.g. too few distinct
+	// x values for the requested degree); fall back to a
+	// weighted mean.
+	var sw, swy float64
+	for i := range xs {
+		sw += w[i]
+		swy += w[i] * ys[i]
+	}
+	return swy / sw
+}
+
+// solveLinear solves the n-by-n linear system a*x = b by Gaussian
+// elimination with partial pivoting, returning nil if a is singular.
+// a and b are modified in place.
+func solveLinear(a [][]float64, b []float64) []float64 {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		piv := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[piv][col]) {
+				piv = r
+			}
+		}
+		if math.Abs(a[piv][col]) < 1e-12 {
+			return nil
+		}
+		a[col], a[piv] = a[piv], a[col]
+		b[col], b[piv] = b[piv], b[col]
+
+		for r := col + 1; r < n; r++ {
+			f := a[r][col] / a[col][col]
+			for c := col; c < n; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+			b[r] -= f * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := b[i]
+		for j := i + 1; j < n; j++ {
+			sum -= a[i][j] * x[j]
+		}
+		x[i] = sum / a[i][i]
+	}
+	return x
+}
+
+// rolling computes a moving window statistic over ys with a window
+// of the given size, using stat to reduce each window.
+func rolling(ys []float64, size int, trailing bool, stat func([]float64) float64) []float64 {
+	n := len(ys)
+	fit := make([]float64, n)
+	for i := range ys {
+		lo, hi := i-size+1, i+1
+		if !trailing {
+			lo, hi = i-(size-1)/2, i+size/2+1
+		}
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > n {
+			hi = n
+		}
+		fit[i] = stat(append([]float64{}, ys[lo:hi]...))
+	}
+	return fit
+}
+
+func mean(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+func median(xs []float64) float64 {
+	sort.Float64s(xs)
+	n := len(xs)
+	if n%2 == 1 {
+		return xs[n/2]
+	}
+	return (xs[n/2-1] + xs[n/2]) / 2
+}
+
+// linearFit computes the least-squares slope and intercept of ys as
+// a function of xs.
+func linearFit(xs, ys []float64) (slope, intercept float64) {
+	n := float64(len(xs))
+	var sx, sy, sxx, sxy float64
+	for i := range xs {
+		sx += xs[i]
+		sy += ys[i]
+		sxx += xs[i] * xs[i]
+		sxy += xs[i] * ys[i]
+	}
+	slope = (n*sxy - sx*sy) / (n*sxx - sx*sx)
+	intercept = (sy - slope*sx) / n
+	return slope, intercept
+}
+
+// linearFitBand computes a confidence band for the fitted line at
+// the given confidence level, using the standard error of the mean
+// prediction at each x and a normal approximation of the critical
+// value.
+func linearFitBand(xs, ys []float64, slope, intercept, level float64) (ymin, ymax []float64) {
+	n := float64(len(xs))
+	var sx, sxx, sse float64
+	for i, x := range xs {
+		sx += x
+		sxx += x * x
+		resid := ys[i] - (slope*x + intercept)
+		sse += resid * resid
+	}
+	meanX := sx / n
+	sXX := sxx - n*meanX*meanX
+	variance := sse / (n - 2)
+
+	z := normalQuantile(1 - (1-level)/2)
+
+	ymin = make([]float64, len(xs))
+	ymax = make([]float64, len(xs))
+	for i, x := range xs {
+		se := math.Sqrt(variance * (1/n + (x-meanX)*(x-meanX)/sXX))
+		fit := slope*x + intercept
+		ymin[i] = fit - z*se
+		ymax[i] = fit + z*se
+	}
+	return ymin, ymax
+}
+
+// normalQuantile approximates the quantile function of the standard
+// normal distribution as sqrt(2)*erfinv(2p-1), using the
+// approximate inverse error function erfinv.
+func normalQuantile(p float64) float64 {
+	if p <= 0 {
+		return math.Inf(-1)
+	}
+	if p >= 1 {
+		return math.Inf(1)
+	}
+	return math.Sqrt2 * erfinv(2*p-1)
+}
+
+// erfinv approximates the inverse error function.
+func erfinv(x float64) float64 {
+	a := 0.147
+	ln := math.Log(1 - x*x)
+	t := 2/(math.Pi*a) + ln/2
+	r := math.Sqrt(math.Sqrt(t*t-ln/a) - t)
+	if x < 0 {
+		return -r
+	}
+	return r
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gg
+
+import "testing"
+
+func closeEnough(a, b, eps float64) bool {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	return d <= eps
+}
+
+func TestLinearFit(t *testing.T) {
+	xs := []float64{0, 1, 2, 3, 4}
+	ys := []float64{1, 3, 5, 7, 9}
+
+	slope, intercept := linearFit(xs, ys)
+	if !closeEnough(slope, 2, 1e-9) || !closeEnough(intercept, 1, 1e-9) {
+		t.Errorf("linearFit = (%v, %v), want (2, 1)", slope, intercept)
+	}
+}
+
+func TestLoessRecoversLine(t *testing.T) {
+	// LOESS over data that's exactly linear should reproduce the
+	// line almost exactly, for both degree 1 and degree 2.
+	xs := make([]float64, 21)
+	ys := make([]float64, 21)
+	for i := range xs {
+		xs[i] = float64(i)
+		ys[i] = 2*xs[i] + 1
+	}
+
+	for _, degree := range []int{1, 2} {
+		fit := loess(xs, ys, 0.5, degree, "gaussian")
+		for i := range fit {
+			if !closeEnough(fit[i], ys[i], 1e-6) {
+				t.Errorf("degree %d: loess[%d] = %v, want %v", degree, i, fit[i], ys[i])
+			}
+		}
+	}
+}
+
+func TestLoessSymmetricHandlesExactFit(t *testing.T) {
+	// A perfectly linear series has a zero residual median in
+	// the "symmetric" robustness loop; this must not produce
+	// NaNs.
+	xs := make([]float64, 10)
+	ys := make([]float64, 10)
+	for i := range xs {
+		xs[i] = float64(i)
+		ys[i] = 3*xs[i] - 2
+	}
+
+	fit := loess(xs, ys, 0.7, 1, "symmetric")
+	for i, v := range fit {
+		if v != v { // NaN check
+			t.Fatalf("loess[%d] is NaN", i)
+		}
+		if !closeEnough(v, ys[i], 1e-6) {
+			t.Errorf("loess[%d] = %v, want %v", i, v, ys[i])
+		}
+	}
+}
+
+func TestRollingMean(t *testing.T) {
+	ys := []float64{1, 2, 3, 4, 5}
+	got := rolling(ys, 3, true, mean)
+	want := []float64{1, 1.5, 2, 3, 4}
+	for i := range want {
+		if !closeEnough(got[i], want[i], 1e-9) {
+			t.Errorf("rolling[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if got := median([]float64{3, 1, 2}); got != 2 {
+		t.Errorf("median(odd) = %v, want 2", got)
+	}
+	if got := median([]float64{1, 2, 3, 4}); got != 2.5 {
+		t.Errorf("median(even) = %v, want 2.5", got)
+	}
+}
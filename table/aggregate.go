@@ -0,0 +1,208 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aclements/go-gg/generic"
+)
+
+// Aggregator reduces one column of a group to a single value, used
+// with Aggregate to implement split-apply-combine style summaries.
+type Aggregator struct {
+	// Name is the name of the output column produced by this
+	// aggregator.
+	Name string
+
+	reduce func(t *Table) interface{}
+}
+
+// Aggregate reduces each leaf group of g to a single row Table by
+// applying aggs to it. Columns that are constant within a group
+// (including columns introduced by a preceding GroupBy) are
+// preserved automatically; all other columns are dropped unless an
+// Aggregator in aggs produces a replacement with the same name.
+//
+// The returned Grouping retains g's group structure, so it composes
+// with Ungroup and Flatten just like the result of GroupBy.
+func Aggregate(g Grouping, aggs ...Aggregator) Grouping {
+	var out GroupingBuilder
+	for _, gid := range g.Tables() {
+		t := g.Table(gid)
+
+		claimed := make(map[string]bool, len(aggs))
+		var b Builder
+		for _, agg := range aggs {
+			if claimed[agg.Name] {
+				panic(fmt.Sprintf("aggregate: two Aggregators both produced column %q", agg.Name))
+			}
+			b.Add(agg.Name, oneRow(agg.reduce(t)))
+			claimed[agg.Name] = true
+		}
+		for _, name := range t.Columns() {
+			if claimed[name] {
+				// An Aggregator already produced a
+				// column with this name; let it win
+				// over the auto-preserved constant.
+				continue
+			}
+			if cv, ok := t.Const(name); ok {
+				b.AddConst(name, cv)
+			}
+		}
+		out.Add(gid, b.Done())
+	}
+	return out.Done()
+}
+
+// oneRow wraps a scalar value in a length-1 slice of its own type,
+// so it can be used as an ordinary column in a single-row Table.
+func oneRow(val interface{}) generic.Slice {
+	seq := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(val)), 1, 1)
+	seq.Index(0).Set(reflect.ValueOf(val))
+	return seq.Interface()
+}
+
+// Count returns an Aggregator that counts the number of rows in
+// each group.
+func Count() Aggregator {
+	return Aggregator{"n", func(t *Table) interface{} {
+		return t.Len()
+	}}
+}
+
+// Sum returns an Aggregator that sums column col within each group.
+// Column col must have a numeric type.
+func Sum(col string) Aggregator {
+	return Reduce(col, func(s generic.Slice) interface{} {
+		sum := 0.0
+		seq := reflect.ValueOf(s)
+		for i := 0; i < seq.Len(); i++ {
+			sum += toFloat(seq.Index(i))
+		}
+		return sum
+	})
+}
+
+// Mean returns an Aggregator that computes the arithmetic mean of
+// column col within each group. Column col must have a numeric
+// type.
+func Mean(col string) Aggregator {
+	return Reduce(col, func(s generic.Slice) interface{} {
+		seq := reflect.ValueOf(s)
+		sum := 0.0
+		for i := 0; i < seq.Len(); i++ {
+			sum += toFloat(seq.Index(i))
+		}
+		return sum / float64(seq.Len())
+	})
+}
+
+// Median returns an Aggregator that computes the median of column
+// col within each group. Column col must have a numeric type.
+func Median(col string) Aggregator {
+	return Reduce(col, func(s generic.Slice) interface{} {
+		seq := reflect.ValueOf(s)
+		xs := make([]float64, seq.Len())
+		for i := range xs {
+			xs[i] = toFloat(seq.Index(i))
+		}
+		generic.Sort(xs)
+		n := len(xs)
+		if n == 0 {
+			return 0.0
+		}
+		if n%2 == 1 {
+			return xs[n/2]
+		}
+		return (xs[n/2-1] + xs[n/2]) / 2
+	})
+}
+
+// Min returns an Aggregator that finds the minimum value of column
+// col within each group. Column col must be orderable.
+func Min(col string) Aggregator {
+	return reduceExtreme(col, true)
+}
+
+// Max returns an Aggregator that finds the maximum value of column
+// col within each group. Column col must be orderable.
+func Max(col string) Aggregator {
+	return reduceExtreme(col, false)
+}
+
+func reduceExtreme(col string, wantMin bool) Aggregator {
+	return Reduce(col, func(s generic.Slice) interface{} {
+		seq := reflect.ValueOf(s)
+		best := seq.Index(0)
+		for i := 1; i < seq.Len(); i++ {
+			v := seq.Index(i)
+			if reflectLess(v, best) == wantMin {
+				best = v
+			}
+		}
+		return best.Interface()
+	})
+}
+
+// reflectLess reports whether a < b, for the orderable kinds that
+// can appear in a table column.
+func reflectLess(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a.Uint() < b.Uint()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	panic("aggregate: non-orderable column")
+}
+
+// First returns an Aggregator that takes the first value of column
+// col within each group.
+func First(col string) Aggregator {
+	return Reduce(col, func(s generic.Slice) interface{} {
+		return reflect.ValueOf(s).Index(0).Interface()
+	})
+}
+
+// NUnique returns an Aggregator that counts the number of distinct
+// values of column col within each group.
+func NUnique(col string) Aggregator {
+	return Reduce(col, func(s generic.Slice) interface{} {
+		seq := reflect.ValueOf(s)
+		seen := make(map[interface{}]bool)
+		for i := 0; i < seq.Len(); i++ {
+			seen[seq.Index(i).Interface()] = true
+		}
+		return len(seen)
+	})
+}
+
+// Reduce returns an Aggregator that applies fn to column name within
+// each group and stores the result in an output column also named
+// name.
+func Reduce(name string, fn func(generic.Slice) interface{}) Aggregator {
+	return Aggregator{name, func(t *Table) interface{} {
+		return fn(t.Column(name))
+	}}
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	}
+	panic("aggregate: non-numeric column")
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"reflect"
+	"testing"
+)
+
+func mustBuild(t *testing.T, cols ...interface{}) *Table {
+	t.Helper()
+	if len(cols)%2 != 0 {
+		t.Fatalf("mustBuild: odd number of arguments")
+	}
+	var b Builder
+	for i := 0; i < len(cols); i += 2 {
+		b.Add(cols[i].(string), cols[i+1])
+	}
+	return b.Done()
+}
+
+func TestAggregateCountSumMean(t *testing.T) {
+	tab := mustBuild(t,
+		"cond", []string{"a", "a", "b"},
+		"x", []float64{1, 2, 10})
+	g := GroupBy(tab, "cond")
+
+	sumX := Sum("x")
+	sumX.Name = "sum_x"
+	meanX := Mean("x")
+	meanX.Name = "mean_x"
+
+	out := Aggregate(g, Count(), sumX, meanX)
+
+	flat := Flatten(out)
+	if got, want := flat.Column("n"), []int{2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("n = %v, want %v", got, want)
+	}
+	if got, want := flat.Column("sum_x"), []float64{3, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sum_x = %v, want %v", got, want)
+	}
+	if got, want := flat.Column("mean_x"), []float64{1.5, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mean_x = %v, want %v", got, want)
+	}
+	if got, want := flat.Column("cond"), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cond = %v, want %v", got, want)
+	}
+}
+
+// TestAggregateNameCollisionBetweenAggregators verifies that Aggregate
+// panics when two Aggregators in the same call produce the same
+// output name, rather than letting the later one silently overwrite
+// the earlier one's column.
+func TestAggregateNameCollisionBetweenAggregators(t *testing.T) {
+	tab := mustBuild(t,
+		"cond", []string{"a", "a", "b"},
+		"x", []float64{1, 2, 10})
+	g := GroupBy(tab, "cond")
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Aggregate did not panic on colliding Aggregator names")
+		}
+	}()
+	Aggregate(g, Sum("x"), Mean("x"))
+}
+
+func TestAggregateNameCollisionWithConst(t *testing.T) {
+	// Aggregating a column that was just promoted to a constant
+	// by GroupBy, using that same column's name as an
+	// Aggregator's output, must not add the column twice.
+	tab := mustBuild(t,
+		"cond", []string{"a", "a", "b"},
+		"x", []float64{1, 2, 10})
+	g := GroupBy(tab, "cond")
+
+	out := Aggregate(g, First("cond"))
+
+	flat := Flatten(out)
+	n := 0
+	for _, name := range flat.Columns() {
+		if name == "cond" {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("column %q appears %d times, want 1", "cond", n)
+	}
+}
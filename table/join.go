@@ -0,0 +1,259 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aclements/go-gg/generic"
+)
+
+// JoinKind selects the matching behavior of Join.
+type JoinKind int
+
+const (
+	// InnerJoin keeps only rows whose "on" columns match in both
+	// left and right.
+	InnerJoin JoinKind = iota
+
+	// LeftJoin keeps every row of left, filling in zero values
+	// for right's columns where there is no match.
+	LeftJoin
+
+	// RightJoin keeps every row of right, filling in zero
+	// values for left's columns where there is no match.
+	RightJoin
+
+	// OuterJoin keeps every row of both left and right, filling
+	// in zero values for the other side's columns where there
+	// is no match.
+	OuterJoin
+)
+
+// Join combines left and right by matching rows whose values in the
+// on columns are equal, operating independently on each pair of
+// Tables that share a GroupID path (a GroupID present in only one of
+// left or right is treated as having an empty Table on the other
+// side).
+//
+// If a column (other than one of the on columns) appears in both
+// left and right, the copy from right is renamed by appending
+// suffix to avoid a collision.
+func Join(left, right Grouping, on []string, kind JoinKind, suffix string) Grouping {
+	// GroupID.Extend never returns a GroupID equal to any other
+	// (even one with the same label), so left and right can't be
+	// matched by comparing GroupIDs directly unless one was built
+	// by literally extending the other's GroupIDs. Instead, match
+	// by the sequence of labels from the root to each GroupID,
+	// which is what actually identifies "the same group" when
+	// left and right come from independently built Groupings.
+	rightTabs := make(map[string]*Table)
+	for _, gid := range right.Tables() {
+		rightTabs[groupPathKey(gid)] = right.Table(gid)
+	}
+
+	// Groups from the same Grouping share a schema (they only
+	// differ in the values of grouped-by columns), so any one
+	// Table from each side is representative enough to build a
+	// full-schema, zero-row stand-in for a group missing on that
+	// side.
+	emptyLeft := emptyTable(representativeTable(left))
+	emptyRight := emptyTable(representativeTable(right))
+
+	var out GroupingBuilder
+	seen := make(map[string]bool)
+	for _, gid := range left.Tables() {
+		key := groupPathKey(gid)
+		seen[key] = true
+		rt, ok := rightTabs[key]
+		if !ok {
+			rt = emptyRight
+		}
+		out.Add(gid, joinTables(left.Table(gid), rt, on, kind, suffix))
+	}
+	if kind == RightJoin || kind == OuterJoin {
+		for _, gid := range right.Tables() {
+			key := groupPathKey(gid)
+			if seen[key] {
+				continue
+			}
+			out.Add(gid, joinTables(emptyLeft, rightTabs[key], on, kind, suffix))
+		}
+	}
+	return out.Done()
+}
+
+// groupPathKey returns a string that uniquely identifies the
+// sequence of labels from RootGroupID to gid, suitable for matching
+// GroupIDs from two different Groupings that represent the same
+// semantic group (for example, two Groupings both produced by
+// GroupBy on the same column from unrelated source tables).
+func groupPathKey(gid GroupID) string {
+	var labels []interface{}
+	for g := gid; g != RootGroupID; g = g.Parent() {
+		labels = append(labels, g.Label())
+	}
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return fmt.Sprint(labels)
+}
+
+// joinTables joins a single pair of Tables that share a GroupID.
+func joinTables(lt, rt *Table, on []string, kind JoinKind, suffix string) *Table {
+	onSet := make(map[string]bool, len(on))
+	for _, name := range on {
+		onSet[name] = true
+	}
+
+	// Hash right rows by the tuple of on column values, like
+	// GroupBy hashes rows by a single column's values.
+	rightCols := make([]generic.Slice, len(on))
+	for i, name := range on {
+		rightCols[i] = rt.MustColumn(name)
+	}
+	rightByKey := make(map[string][]int)
+	for j := 0; j < rt.Len(); j++ {
+		key := joinKey(rightCols, j)
+		rightByKey[key] = append(rightByKey[key], j)
+	}
+
+	leftCols := make([]generic.Slice, len(on))
+	for i, name := range on {
+		leftCols[i] = lt.MustColumn(name)
+	}
+
+	// leftNull and rightNull are sentinel row indices, one past
+	// the end of each table, standing in for "no matching row".
+	// After streaming, the tables are extended with a single
+	// zero-valued row so these indices are valid when the output
+	// columns are gathered.
+	leftNull, rightNull := lt.Len(), rt.Len()
+
+	var leftIdx, rightIdx []int
+	matchedRight := make([]bool, rt.Len())
+	for i := 0; i < lt.Len(); i++ {
+		matches := rightByKey[joinKey(leftCols, i)]
+		if len(matches) == 0 {
+			if kind == LeftJoin || kind == OuterJoin {
+				leftIdx = append(leftIdx, i)
+				rightIdx = append(rightIdx, rightNull)
+			}
+			continue
+		}
+		for _, j := range matches {
+			matchedRight[j] = true
+			leftIdx = append(leftIdx, i)
+			rightIdx = append(rightIdx, j)
+		}
+	}
+	if kind == RightJoin || kind == OuterJoin {
+		for j, ok := range matchedRight {
+			if !ok {
+				leftIdx = append(leftIdx, leftNull)
+				rightIdx = append(rightIdx, j)
+			}
+		}
+	}
+
+	elt := concatRows(lt, zeroRow(lt))
+	ert := concatRows(rt, zeroRow(rt))
+
+	var b Builder
+	for _, name := range lt.Columns() {
+		if onSet[name] {
+			b.Add(name, mergeOn(elt.Column(name), ert.MustColumn(name), leftIdx, rightIdx, leftNull))
+			continue
+		}
+		b.Add(name, generic.MultiIndex(elt.Column(name), leftIdx))
+	}
+	for _, name := range rt.Columns() {
+		if onSet[name] {
+			continue
+		}
+		oname := name
+		if hasColumn(lt, name) {
+			oname = name + suffix
+		}
+		b.Add(oname, generic.MultiIndex(ert.Column(name), rightIdx))
+	}
+	return b.Done()
+}
+
+func hasColumn(t *Table, name string) bool {
+	for _, c := range t.Columns() {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeOn builds an on-column for the joined output. The on columns
+// are equal for every matched row, but for an unmatched right-only
+// row leftSeq holds the zero value, so the right side's value must
+// be used instead.
+func mergeOn(leftSeq, rightSeq generic.Slice, leftIdx, rightIdx []int, leftNull int) generic.Slice {
+	lv := reflect.ValueOf(leftSeq)
+	rv := reflect.ValueOf(rightSeq)
+	out := reflect.MakeSlice(reflect.TypeOf(leftSeq), len(leftIdx), len(leftIdx))
+	for i := range leftIdx {
+		if leftIdx[i] == leftNull {
+			out.Index(i).Set(rv.Index(rightIdx[i]))
+		} else {
+			out.Index(i).Set(lv.Index(leftIdx[i]))
+		}
+	}
+	return out.Interface()
+}
+
+// joinKey computes a hashable key from the values of cols at row i.
+func joinKey(cols []generic.Slice, i int) string {
+	vals := make([]interface{}, len(cols))
+	for j, c := range cols {
+		vals[j] = reflect.ValueOf(c).Index(i).Interface()
+	}
+	return fmt.Sprint(vals)
+}
+
+// representativeTable returns an arbitrary Table from g, or an empty
+// Table if g has none. Since every group of a Grouping shares the
+// same columns, any one Table stands in for "the schema of g".
+func representativeTable(g Grouping) *Table {
+	tabs := g.Tables()
+	if len(tabs) == 0 {
+		return new(Table)
+	}
+	return g.Table(tabs[0])
+}
+
+// emptyTable returns a zero-row Table with the same columns as like,
+// standing in for the missing side of a Join when a GroupID has no
+// match on the other side. Giving it like's full schema (rather than
+// just the on columns) keeps every output group's column set
+// consistent, whether or not that group matched.
+func emptyTable(like *Table) *Table {
+	var b Builder
+	for _, name := range like.Columns() {
+		elemType := reflect.TypeOf(like.Column(name)).Elem()
+		b.Add(name, reflect.MakeSlice(reflect.SliceOf(elemType), 0, 0).Interface())
+	}
+	return b.Done()
+}
+
+// zeroRow returns a single-row Table with the same columns as t, all
+// set to their zero value. It's used to fill in the non-key columns
+// of rows that have no match on the other side of a Join.
+func zeroRow(t *Table) *Table {
+	var b Builder
+	for _, name := range t.Columns() {
+		seq := t.Column(name)
+		elemType := reflect.TypeOf(seq).Elem()
+		zero := reflect.MakeSlice(reflect.SliceOf(elemType), 1, 1)
+		b.Add(name, zero.Interface())
+	}
+	return b.Done()
+}
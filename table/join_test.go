@@ -0,0 +1,111 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJoinInner(t *testing.T) {
+	left := mustBuild(t,
+		"id", []int{1, 2, 3},
+		"x", []float64{10, 20, 30})
+	right := mustBuild(t,
+		"id", []int{2, 3, 4},
+		"y", []float64{200, 300, 400})
+
+	out := Join(left, right, []string{"id"}, InnerJoin, ".r")
+	flat := Flatten(out)
+
+	if got, want := flat.Column("id"), []int{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("id = %v, want %v", got, want)
+	}
+	if got, want := flat.Column("x"), []float64{20, 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("x = %v, want %v", got, want)
+	}
+	if got, want := flat.Column("y"), []float64{200, 300}; !reflect.DeepEqual(got, want) {
+		t.Errorf("y = %v, want %v", got, want)
+	}
+}
+
+func TestJoinLeft(t *testing.T) {
+	left := mustBuild(t,
+		"id", []int{1, 2, 3},
+		"x", []float64{10, 20, 30})
+	right := mustBuild(t,
+		"id", []int{2},
+		"y", []float64{200})
+
+	out := Join(left, right, []string{"id"}, LeftJoin, ".r")
+	flat := Flatten(out)
+
+	if got, want := flat.Column("id"), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("id = %v, want %v", got, want)
+	}
+	if got, want := flat.Column("y"), []float64{0, 200, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("y = %v, want %v", got, want)
+	}
+}
+
+// TestJoinGrouped verifies that Join matches groups from two
+// independently built Groupings by their label path, not by GroupID
+// identity (GroupID.Extend never produces an equal GroupID, so two
+// separate GroupBy calls never share GroupIDs even when they group
+// by the same values).
+func TestJoinGrouped(t *testing.T) {
+	left := mustBuild(t,
+		"cond", []string{"a", "a", "b"},
+		"x", []float64{1, 2, 10})
+	right := mustBuild(t,
+		"cond", []string{"a", "b"},
+		"label", []string{"alpha", "beta"})
+
+	lg := GroupBy(left, "cond")
+	rg := GroupBy(right, "cond")
+
+	out := Join(lg, rg, []string{"cond"}, InnerJoin, ".r")
+	flat := Flatten(Ungroup(out))
+
+	if got, want := flat.Column("label"), []string{"alpha", "alpha", "beta"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("label = %v, want %v (groups didn't match across independently built Groupings)", got, want)
+	}
+}
+
+// TestJoinGroupedMismatch verifies that Join tolerates a GroupID
+// present in only one of left or right, treating the missing side as
+// an empty Table rather than panicking, for every JoinKind.
+func TestJoinGroupedMismatch(t *testing.T) {
+	left := mustBuild(t,
+		"cond", []string{"a", "a", "b"},
+		"x", []float64{1, 2, 10})
+	right := mustBuild(t,
+		"cond", []string{"a"},
+		"label", []string{"alpha"})
+
+	lg := GroupBy(left, "cond")
+	rg := GroupBy(right, "cond")
+
+	leftOut := Flatten(Ungroup(Join(lg, rg, []string{"cond"}, LeftJoin, ".r")))
+	if got, want := leftOut.Column("cond"), []string{"a", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LeftJoin cond = %v, want %v", got, want)
+	}
+	if got, want := leftOut.Column("label"), []string{"alpha", "alpha", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LeftJoin label = %v, want %v", got, want)
+	}
+
+	rightOut := Flatten(Ungroup(Join(lg, rg, []string{"cond"}, RightJoin, ".r")))
+	if got, want := rightOut.Column("cond"), []string{"a", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RightJoin cond = %v, want %v", got, want)
+	}
+
+	outerOut := Flatten(Ungroup(Join(lg, rg, []string{"cond"}, OuterJoin, ".r")))
+	if got, want := outerOut.Column("cond"), []string{"a", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OuterJoin cond = %v, want %v", got, want)
+	}
+	if got, want := outerOut.Column("label"), []string{"alpha", "alpha", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OuterJoin label = %v, want %v", got, want)
+	}
+}
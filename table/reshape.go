@@ -0,0 +1,186 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aclements/go-gg/generic"
+)
+
+// Pivot reshapes each leaf group of g from long to wide form: the
+// distinct values of column key become new columns, each holding
+// the corresponding value from column value. All other columns
+// identify a row; rows that agree on every other column are
+// combined into a single output row. If more than one input row
+// maps to the same (identity, key) pair, the last one wins.
+//
+// Pivot is the inverse of Unpivot.
+func Pivot(g Grouping, key, value string) Grouping {
+	var out GroupingBuilder
+	for _, gid := range g.Tables() {
+		t := g.Table(gid)
+
+		var idCols []string
+		for _, name := range t.Columns() {
+			if name == key || name == value {
+				continue
+			}
+			idCols = append(idCols, name)
+		}
+		idParts := make([]generic.Slice, len(idCols))
+		for i, name := range idCols {
+			idParts[i] = t.MustColumn(name)
+		}
+		keyCol := reflect.ValueOf(t.MustColumn(key))
+
+		// Find the distinct row identities and the distinct key
+		// values, both in order of first appearance, and record
+		// which input row holds the value for each (id, key)
+		// pair.
+		var ids []string
+		idRow := make(map[string]int)
+		cellRow := make(map[string]map[string]int)
+		var keys []string
+		keySeen := make(map[string]bool)
+		for i := 0; i < t.Len(); i++ {
+			idStr := joinKey(idParts, i)
+			if _, ok := idRow[idStr]; !ok {
+				idRow[idStr] = i
+				ids = append(ids, idStr)
+				cellRow[idStr] = make(map[string]int)
+			}
+			keyStr := fmt.Sprint(keyCol.Index(i).Interface())
+			if !keySeen[keyStr] {
+				keySeen[keyStr] = true
+				keys = append(keys, keyStr)
+			}
+			cellRow[idStr][keyStr] = i
+		}
+
+		idRows := make([]int, len(ids))
+		for i, id := range ids {
+			idRows[i] = idRow[id]
+		}
+
+		var b Builder
+		for _, name := range idCols {
+			if cv, ok := t.Const(name); ok {
+				b.AddConst(name, cv)
+				continue
+			}
+			b.Add(name, generic.MultiIndex(t.Column(name), idRows))
+		}
+
+		// Extend t with a zero-valued row so that (id, key)
+		// pairs with no matching input row can be indexed to a
+		// zero value rather than requiring special-case code.
+		extT := concatRows(t, zeroRow(t))
+		nullRow := t.Len()
+		valCol := extT.Column(value)
+		for _, k := range keys {
+			rows := make([]int, len(ids))
+			for i, id := range ids {
+				if r, ok := cellRow[id][k]; ok {
+					rows[i] = r
+				} else {
+					rows[i] = nullRow
+				}
+			}
+			b.Add(k, generic.MultiIndex(valCol, rows))
+		}
+
+		out.Add(gid, b.Done())
+	}
+	return out.Done()
+}
+
+// Unpivot reshapes each leaf group of g from wide to long form:
+// cols are stacked into two new columns, varName (holding the name
+// of the column each value came from) and valueName (holding the
+// value itself). All other columns are replicated once per stacked
+// column so they continue to identify each original row.
+//
+// If the columns in cols don't all have the same element type,
+// valueName falls back to an interface{} column rather than failing.
+//
+// Unpivot is the inverse of Pivot.
+func Unpivot(g Grouping, valueName, varName string, cols ...string) Grouping {
+	stacked := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		stacked[c] = true
+	}
+
+	var out GroupingBuilder
+	for _, gid := range g.Tables() {
+		t := g.Table(gid)
+		n := t.Len()
+
+		var elemType reflect.Type
+		homogeneous := true
+		for _, c := range cols {
+			et := reflect.TypeOf(t.Column(c)).Elem()
+			if elemType == nil {
+				elemType = et
+			} else if et != elemType {
+				homogeneous = false
+			}
+		}
+
+		// idx repeats 0..n-1 once per stacked column, so
+		// MultiIndex replicates each identifying column's
+		// values once per entry in cols.
+		idx := make([]int, 0, n*len(cols))
+		for range cols {
+			for i := 0; i < n; i++ {
+				idx = append(idx, i)
+			}
+		}
+
+		var b Builder
+		for _, name := range t.Columns() {
+			if stacked[name] {
+				continue
+			}
+			if cv, ok := t.Const(name); ok {
+				b.AddConst(name, cv)
+				continue
+			}
+			b.Add(name, generic.MultiIndex(t.Column(name), idx))
+		}
+
+		varSeq := make([]string, 0, n*len(cols))
+		for _, c := range cols {
+			for i := 0; i < n; i++ {
+				varSeq = append(varSeq, c)
+			}
+		}
+		b.Add(varName, varSeq)
+
+		if homogeneous {
+			valSeq := reflect.MakeSlice(reflect.SliceOf(elemType), 0, n*len(cols))
+			for _, c := range cols {
+				cv := reflect.ValueOf(t.Column(c))
+				for i := 0; i < n; i++ {
+					valSeq = reflect.Append(valSeq, cv.Index(i))
+				}
+			}
+			b.Add(valueName, valSeq.Interface())
+		} else {
+			valSeq := make([]interface{}, 0, n*len(cols))
+			for _, c := range cols {
+				cv := reflect.ValueOf(t.Column(c))
+				for i := 0; i < n; i++ {
+					valSeq = append(valSeq, cv.Index(i).Interface())
+				}
+			}
+			b.Add(valueName, valSeq)
+		}
+
+		out.Add(gid, b.Done())
+	}
+	return out.Done()
+}
@@ -0,0 +1,43 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnpivotPivotRoundTrip(t *testing.T) {
+	wide := mustBuild(t,
+		"id", []int{1, 2},
+		"a", []float64{10, 30},
+		"b", []float64{20, 40})
+
+	long := Unpivot(wide, "value", "variable", "a", "b")
+	flat := Flatten(long)
+
+	if got, want := flat.Column("id"), []int{1, 2, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("id = %v, want %v", got, want)
+	}
+	if got, want := flat.Column("variable"), []string{"a", "a", "b", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("variable = %v, want %v", got, want)
+	}
+	if got, want := flat.Column("value"), []float64{10, 30, 20, 40}; !reflect.DeepEqual(got, want) {
+		t.Errorf("value = %v, want %v", got, want)
+	}
+
+	back := Pivot(long, "variable", "value")
+	backFlat := Flatten(back)
+
+	if got, want := backFlat.Column("id"), []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip id = %v, want %v", got, want)
+	}
+	if got, want := backFlat.Column("a"), []float64{10, 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip a = %v, want %v", got, want)
+	}
+	if got, want := backFlat.Column("b"), []float64{20, 40}; !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip b = %v, want %v", got, want)
+	}
+}